@@ -0,0 +1,74 @@
+package raus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a single pub/sub message delivered on a Backend's Subscribe
+// channel.
+type Message struct {
+	Payload string
+}
+
+// Backend is the storage and messaging substrate a *Raus claims ids
+// against. RedisBackend is the default; MemoryBackend is an in-process
+// stand-in for tests, and BoltBackend is an embedded option for
+// single-node deployments that would rather not run Redis at all.
+type Backend interface {
+	// TryClaim atomically locks key for uuid, expiring after ttl, and
+	// reports whether the lock was acquired.
+	TryClaim(ctx context.Context, key, uuid string, ttl time.Duration) (bool, error)
+	// ClaimInRange atomically picks and locks a free id in [min,max] for
+	// uuid under namespace, returning ErrorID if every id in the range is
+	// currently held.
+	ClaimInRange(ctx context.Context, namespace string, min, max int, uuid string, ttl time.Duration) (int, error)
+	// ListFree returns up to limit currently-unlocked ids in [min,max]
+	// under namespace, without locking any of them. It backs pick
+	// strategies, such as rendezvous hashing, that choose among several
+	// candidates before claiming one.
+	ListFree(ctx context.Context, namespace string, min, max, limit int) ([]int, error)
+	// Refresh extends the ttl on a lock uuid already holds.
+	Refresh(ctx context.Context, key, uuid string, ttl time.Duration) error
+	// Release drops a lock uuid holds.
+	Release(ctx context.Context, key, uuid string) error
+	// Publish broadcasts payload on channel.
+	Publish(ctx context.Context, channel, payload string) error
+	// Subscribe returns a channel of Messages published on channel; it is
+	// closed once ctx is canceled.
+	Subscribe(ctx context.Context, channel string) (<-chan Message, error)
+}
+
+// hashTag wraps namespace in Redis Cluster hash tag braces so everything
+// derived from it lands on the same Cluster slot. Backends that don't
+// shard by key (MemoryBackend, BoltBackend) ignore the braces.
+func hashTag(namespace string) string {
+	return "{" + namespace + "}"
+}
+
+func lockKey(namespace string, id int) string {
+	return fmt.Sprintf("%s:id:%d", hashTag(namespace), id)
+}
+
+func broadcastChannel(namespace string) string {
+	return hashTag(namespace) + pubSubChannelSuffix
+}
+
+func parsePayload(payload string) (string, int, error) {
+	s := strings.Split(payload, ":")
+	if len(s) != 2 {
+		return "", 0, fmt.Errorf("unexpected data %s", payload)
+	}
+	id, err := strconv.Atoi(s[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected data %s", payload)
+	}
+	return s[0], id, nil
+}
+
+func newPayload(uuid string, id int) string {
+	return fmt.Sprintf("%s:%d", uuid, id)
+}
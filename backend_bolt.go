@@ -0,0 +1,137 @@
+package raus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var locksBucket = []byte("raus_locks")
+
+// BoltBackend is an embedded Backend backed by a local BoltDB file, for
+// single-node deployments that would rather not run Redis just to
+// coordinate ids within one process.
+type BoltBackend struct {
+	*localPubSub
+
+	db *bolt.DB
+}
+
+// NewBoltBackend opens (creating if necessary) the BoltDB file at path.
+func NewBoltBackend(path string) (*BoltBackend, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(locksBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltBackend{localPubSub: newLocalPubSub(), db: db}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *BoltBackend) TryClaim(ctx context.Context, key, uuid string, ttl time.Duration) (bool, error) {
+	claimed := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(locksBucket)
+		if held, expires := decodeLock(bucket.Get([]byte(key))); held != "" && expires.After(time.Now()) {
+			return nil
+		}
+		claimed = true
+		return bucket.Put([]byte(key), encodeLock(uuid, time.Now().Add(ttl)))
+	})
+	return claimed, err
+}
+
+func (b *BoltBackend) ClaimInRange(ctx context.Context, namespace string, min, max int, uuid string, ttl time.Duration) (int, error) {
+	id := ErrorID
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(locksBucket)
+		now := time.Now()
+		for i := min; i <= max; i++ {
+			key := []byte(lockKey(namespace, i))
+			if held, expires := decodeLock(bucket.Get(key)); held != "" && expires.After(now) {
+				continue
+			}
+			if err := bucket.Put(key, encodeLock(uuid, now.Add(ttl))); err != nil {
+				return err
+			}
+			id = i
+			return nil
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (b *BoltBackend) ListFree(ctx context.Context, namespace string, min, max, limit int) ([]int, error) {
+	var free []int
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(locksBucket)
+		now := time.Now()
+		for id := min; id <= max; id++ {
+			if held, expires := decodeLock(bucket.Get([]byte(lockKey(namespace, id)))); held != "" && expires.After(now) {
+				continue
+			}
+			free = append(free, id)
+			if len(free) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return free, err
+}
+
+func (b *BoltBackend) Refresh(ctx context.Context, key, uuid string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(locksBucket)
+		if held, _ := decodeLock(bucket.Get([]byte(key))); held != uuid {
+			return fmt.Errorf("raus: lock %s not held by %s", key, uuid)
+		}
+		return bucket.Put([]byte(key), encodeLock(uuid, time.Now().Add(ttl)))
+	})
+}
+
+func (b *BoltBackend) Release(ctx context.Context, key, uuid string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(locksBucket)
+		if held, _ := decodeLock(bucket.Get([]byte(key))); held != uuid {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func encodeLock(uuid string, expires time.Time) []byte {
+	return []byte(uuid + ":" + strconv.FormatInt(expires.UnixNano(), 10))
+}
+
+func decodeLock(raw []byte) (string, time.Time) {
+	if raw == nil {
+		return "", time.Time{}
+	}
+	s := string(raw)
+	idx := strings.LastIndex(s, ":")
+	if idx < 0 {
+		return "", time.Time{}
+	}
+	nanos, err := strconv.ParseInt(s[idx+1:], 10, 64)
+	if err != nil {
+		return "", time.Time{}
+	}
+	return s[:idx], time.Unix(0, nanos)
+}
@@ -0,0 +1,105 @@
+package raus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is an in-process Backend with no external dependencies.
+// It exists for tests that want to exercise Raus's claiming logic without
+// standing up a real Redis.
+type MemoryBackend struct {
+	*localPubSub
+
+	mu    sync.Mutex
+	locks map[string]memoryLock
+}
+
+type memoryLock struct {
+	uuid    string
+	expires time.Time
+}
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		localPubSub: newLocalPubSub(),
+		locks:       make(map[string]memoryLock),
+	}
+}
+
+func (b *MemoryBackend) held(key string, now time.Time) (memoryLock, bool) {
+	lock, ok := b.locks[key]
+	return lock, ok && lock.expires.After(now)
+}
+
+func (b *MemoryBackend) TryClaim(ctx context.Context, key, uuid string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := b.held(key, now); ok {
+		return false, nil
+	}
+	b.locks[key] = memoryLock{uuid: uuid, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (b *MemoryBackend) ClaimInRange(ctx context.Context, namespace string, min, max int, uuid string, ttl time.Duration) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for id := min; id <= max; id++ {
+		key := lockKey(namespace, id)
+		if _, ok := b.held(key, now); ok {
+			continue
+		}
+		b.locks[key] = memoryLock{uuid: uuid, expires: now.Add(ttl)}
+		return id, nil
+	}
+	return ErrorID, nil
+}
+
+func (b *MemoryBackend) ListFree(ctx context.Context, namespace string, min, max, limit int) ([]int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	free := make([]int, 0, limit)
+	for id := min; id <= max; id++ {
+		if _, ok := b.held(lockKey(namespace, id), now); ok {
+			continue
+		}
+		free = append(free, id)
+		if len(free) >= limit {
+			break
+		}
+	}
+	return free, nil
+}
+
+func (b *MemoryBackend) Refresh(ctx context.Context, key, uuid string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.locks[key]
+	if !ok || lock.uuid != uuid {
+		return errors.New("raus: lock not held")
+	}
+	lock.expires = time.Now().Add(ttl)
+	b.locks[key] = lock
+	return nil
+}
+
+func (b *MemoryBackend) Release(ctx context.Context, key, uuid string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lock, ok := b.locks[key]; ok && lock.uuid == uuid {
+		delete(b.locks, key)
+	}
+	return nil
+}
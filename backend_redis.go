@@ -0,0 +1,206 @@
+package raus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBackend is the default Backend. It wraps a go-redis
+// redis.UniversalClient, so the same *RedisBackend works whether that
+// client points at a standalone node, a Sentinel failover group, or a
+// Cluster.
+type RedisBackend struct {
+	client redis.UniversalClient
+}
+
+// NewRedisBackend wraps an already-configured redis.UniversalClient.
+func NewRedisBackend(client redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{client: client}
+}
+
+func (b *RedisBackend) TryClaim(ctx context.Context, key, uuid string, ttl time.Duration) (bool, error) {
+	res := b.client.SetNX(ctx, key, uuid, ttl)
+	return res.Val(), res.Err()
+}
+
+// claimScript atomically picks the lowest free id in [min,max] and locks
+// it, eliminating the listen-then-SETNX race where two callers could both
+// observe the same id as free. KEYS[1] is the hash-tagged namespace so the
+// whole scan, and the eventual SET, stay on a single Cluster slot.
+const claimScript = `
+local namespace = KEYS[1]
+local min = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+local uuid = ARGV[3]
+local ttl = tonumber(ARGV[4])
+
+local used = {}
+local cursor = "0"
+repeat
+	local res = redis.call("SCAN", cursor, "MATCH", namespace .. ":id:*", "COUNT", 1000)
+	cursor = res[1]
+	for _, key in ipairs(res[2]) do
+		local id = tonumber(string.match(key, ":id:(%d+)$"))
+		if id then
+			used[id] = true
+		end
+	end
+until cursor == "0"
+
+for id = min, max do
+	if not used[id] then
+		if redis.call("SET", namespace .. ":id:" .. id, uuid, "NX", "EX", ttl) then
+			return id
+		end
+	end
+end
+return nil
+`
+
+func (b *RedisBackend) ClaimInRange(ctx context.Context, namespace string, min, max int, uuid string, ttl time.Duration) (int, error) {
+	res, err := b.client.Eval(ctx, claimScript, []string{hashTag(namespace)}, min, max, uuid, int(ttl.Seconds())).Result()
+	if err == redis.Nil {
+		return ErrorID, nil
+	}
+	if err != nil {
+		return ErrorID, err
+	}
+	id, ok := res.(int64)
+	if !ok {
+		return ErrorID, nil
+	}
+	return int(id), nil
+}
+
+// listFreeScript is claimScript's read-only sibling: it scans for free
+// ids but never claims one, so the caller can pick among them (e.g. via
+// rendezvous hashing) before claiming with a separate TryClaim.
+const listFreeScript = `
+local namespace = KEYS[1]
+local min = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+local used = {}
+local cursor = "0"
+repeat
+	local res = redis.call("SCAN", cursor, "MATCH", namespace .. ":id:*", "COUNT", 1000)
+	cursor = res[1]
+	for _, key in ipairs(res[2]) do
+		local id = tonumber(string.match(key, ":id:(%d+)$"))
+		if id then
+			used[id] = true
+		end
+	end
+until cursor == "0"
+
+local free = {}
+for id = min, max do
+	if not used[id] then
+		table.insert(free, id)
+		if #free >= limit then
+			break
+		end
+	end
+end
+return free
+`
+
+func (b *RedisBackend) ListFree(ctx context.Context, namespace string, min, max, limit int) ([]int, error) {
+	res, err := b.client.Eval(ctx, listFreeScript, []string{hashTag(namespace)}, min, max, limit).Result()
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := res.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	ids := make([]int, 0, len(raw))
+	for _, v := range raw {
+		id, ok := v.(int64)
+		if !ok {
+			continue
+		}
+		ids = append(ids, int(id))
+	}
+	return ids, nil
+}
+
+// refreshScript extends key's ttl only if uuid is still the current
+// owner, so a heartbeat delayed past a peer's TTL can't steal the key
+// back out from under them.
+const refreshScript = `
+local key = KEYS[1]
+local uuid = ARGV[1]
+local ttl = tonumber(ARGV[2])
+if redis.call("GET", key) ~= uuid then
+	return 0
+end
+redis.call("SET", key, uuid, "EX", ttl)
+return 1
+`
+
+func (b *RedisBackend) Refresh(ctx context.Context, key, uuid string, ttl time.Duration) error {
+	res, err := b.client.Eval(ctx, refreshScript, []string{key}, uuid, int(ttl.Seconds())).Result()
+	if err != nil {
+		return err
+	}
+	if held, ok := res.(int64); !ok || held == 0 {
+		return fmt.Errorf("raus: lock %s not held by %s", key, uuid)
+	}
+	return nil
+}
+
+// releaseScript deletes key only if uuid is still the current owner, so
+// releasing a lock whose TTL already lapsed and was re-claimed by a peer
+// can't delete that peer's active lock out from under them.
+const releaseScript = `
+local key = KEYS[1]
+local uuid = ARGV[1]
+if redis.call("GET", key) ~= uuid then
+	return 0
+end
+redis.call("DEL", key)
+return 1
+`
+
+func (b *RedisBackend) Release(ctx context.Context, key, uuid string) error {
+	// mirrors MemoryBackend/BoltBackend: releasing a lock we no longer
+	// own is a no-op, not an error.
+	return b.client.Eval(ctx, releaseScript, []string{key}, uuid).Err()
+}
+
+func (b *RedisBackend) Publish(ctx context.Context, channel, payload string) error {
+	return b.client.Publish(ctx, channel, payload).Err()
+}
+
+func (b *RedisBackend) Subscribe(ctx context.Context, channel string) (<-chan Message, error) {
+	pubsub := b.client.Subscribe(ctx, channel)
+	out := make(chan Message)
+
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+		in := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- Message{Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
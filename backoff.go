@@ -0,0 +1,23 @@
+package raus
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 30 * time.Second
+)
+
+// backoffDuration returns a full-jitter exponential backoff for the given
+// 0-indexed attempt, capped at backoffMax, so a flaky Redis (or a
+// thundering herd of peers recovering together) doesn't get hammered by
+// every Raus on the same tight heartbeat interval.
+func backoffDuration(attempt int) time.Duration {
+	d := backoffBase << uint(attempt)
+	if d <= 0 || d > backoffMax {
+		d = backoffMax
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,66 @@
+package raus
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	claimRetryInterval = 200 * time.Millisecond
+	maxClaimAttempts   = 10
+)
+
+// claim picks and locks a free id in [min,max], returning ErrorID if the
+// range is currently full (or, for the rendezvous path, if we lost the
+// race to claim the id we picked).
+func (r *Raus) claim(ctx context.Context) (int, error) {
+	if r.rendezvousKey != "" {
+		return r.claimRendezvous(ctx)
+	}
+	return r.backend.ClaimInRange(ctx, r.namespace, r.min, r.max, r.uuid, r.ttl)
+}
+
+// claimRendezvous lists the free candidates in range and picks among them
+// with rendezvous hashing instead of leaving the pick to the backend.
+func (r *Raus) claimRendezvous(ctx context.Context) (int, error) {
+	candidates, err := r.backend.ListFree(ctx, r.namespace, r.min, r.max, maxCandidate)
+	if err != nil {
+		return ErrorID, err
+	}
+	if len(candidates) == 0 {
+		return ErrorID, nil
+	}
+
+	id := rendezvousPick(r.rendezvousKey, candidates)
+	ok, err := r.backend.TryClaim(ctx, lockKey(r.namespace, id), r.uuid, r.ttl)
+	if err != nil {
+		return ErrorID, err
+	}
+	if !ok {
+		// lost the race to another peer; the caller retries.
+		return ErrorID, nil
+	}
+	return id, nil
+}
+
+// claimWithRetry retries claim a bounded number of times, since a nil
+// result can mean either the range is full or we lost a race to a peer
+// under Cluster (where the scan and the claim aren't on the same node).
+func (r *Raus) claimWithRetry(ctx context.Context) (int, error) {
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		id, err := r.claim(ctx)
+		if err != nil {
+			return ErrorID, err
+		}
+		if id != ErrorID {
+			return id, nil
+		}
+		select {
+		case <-ctx.Done():
+			return ErrorID, errors.New("canceled")
+		case <-time.After(claimRetryInterval):
+		}
+	}
+	return ErrorID, errors.New("no more available id")
+}
@@ -0,0 +1,70 @@
+package raus
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// parseURI parses a space-separated "key=value" connection string into a
+// *redis.UniversalOptions. redis.NewUniversalClient picks the right client
+// (single node, Sentinel, or Cluster) based on the fields that end up set:
+// MasterName present -> Sentinel, more than one address -> Cluster,
+// otherwise a plain single-node client.
+//
+// Recognized keys: addrs (required, comma-separated host:port list),
+// db, password, tls (bool), master_name, idle_timeout (duration).
+func parseURI(uri string) (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{}
+	var sawAddrs bool
+
+	for _, field := range strings.Fields(uri) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("raus: malformed connection string field %q", field)
+		}
+		key, val := kv[0], kv[1]
+
+		switch key {
+		case "addrs":
+			opts.Addrs = strings.Split(val, ",")
+			sawAddrs = true
+		case "db":
+			db, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("raus: invalid db %q: %w", val, err)
+			}
+			opts.DB = db
+		case "password":
+			opts.Password = val
+		case "tls":
+			enabled, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, fmt.Errorf("raus: invalid tls %q: %w", val, err)
+			}
+			if enabled {
+				opts.TLSConfig = &tls.Config{}
+			}
+		case "master_name":
+			opts.MasterName = val
+		case "idle_timeout":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("raus: invalid idle_timeout %q: %w", val, err)
+			}
+			opts.IdleTimeout = d
+		default:
+			return nil, fmt.Errorf("raus: unknown connection string key %q", key)
+		}
+	}
+
+	if !sawAddrs {
+		return nil, fmt.Errorf("raus: connection string %q is missing addrs", uri)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,47 @@
+package raus
+
+// EventKind categorizes the lifecycle transitions a Session reports on
+// Events().
+type EventKind int
+
+const (
+	// Acquired fires once, when the id is first claimed.
+	Acquired EventKind = iota
+	// Refreshed fires after a successful TTL refresh of the lock key.
+	Refreshed
+	// Republished fires after a successful heartbeat publish.
+	Republished
+	// PeerSeen fires whenever another Raus's heartbeat is observed on the
+	// pub/sub channel.
+	PeerSeen
+	// Released fires once the lock key has been deleted during Close.
+	Released
+	// LockLost fires when a duplicate id is detected on the pub/sub
+	// channel, meaning another peer believes it holds this id too.
+	LockLost
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Acquired:
+		return "Acquired"
+	case Refreshed:
+		return "Refreshed"
+	case Republished:
+		return "Republished"
+	case PeerSeen:
+		return "PeerSeen"
+	case Released:
+		return "Released"
+	case LockLost:
+		return "LockLost"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a single lifecycle notification delivered on Session.Events().
+type Event struct {
+	Kind EventKind
+	ID   int
+}
@@ -0,0 +1,26 @@
+package raus
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	claimsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "raus_claims_total",
+		Help: "Total number of ids successfully claimed.",
+	})
+	duplicateDetectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "raus_duplicate_detected_total",
+		Help: "Total number of times a duplicate id was detected via the pub/sub safety net.",
+	})
+	refreshFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "raus_refresh_failures_total",
+		Help: "Total number of failed lock refresh attempts.",
+	})
+	rangeUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "raus_range_utilization",
+		Help: "Fraction of the configured id range claimed, labeled by namespace and refreshed on every heartbeat, so operators can alert on range exhaustion before \"no more available id\" fires.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	prometheus.MustRegister(claimsTotal, duplicateDetectedTotal, refreshFailuresTotal, rangeUtilization)
+}
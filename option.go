@@ -0,0 +1,36 @@
+package raus
+
+import "time"
+
+// Option configures optional behavior on a *Raus at construction time.
+type Option func(*Raus)
+
+// WithTTL overrides the default 60 second lock expiration.
+func WithTTL(ttl time.Duration) Option {
+	return func(r *Raus) {
+		r.ttl = ttl
+	}
+}
+
+// WithHeartbeatInterval overrides the default 1 second interval at which
+// the heartbeat is republished and the lock's TTL is refreshed.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(r *Raus) {
+		r.heartbeatInterval = interval
+	}
+}
+
+// WithRendezvousPick replaces the default lowest-free-id claim with
+// rendezvous (HRW) hashing: among the lowest maxCandidate (10) free ids
+// currently available, the id whose hash of nodeKey ("hostname", a pod name, or the Raus's own
+// uuid) is largest is the one claimed. Because a candidate's weight only
+// depends on nodeKey and itself, adding or removing a peer or a candidate
+// only reshuffles about 1/N winners instead of all of them, and the same
+// (nodeKey, candidates) pair always picks the same id, which makes tests
+// reproducible. The default remains the backend's own lowest-free-id
+// claim so existing callers are unaffected.
+func WithRendezvousPick(nodeKey string) Option {
+	return func(r *Raus) {
+		r.rendezvousKey = nodeKey
+	}
+}
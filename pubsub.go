@@ -0,0 +1,55 @@
+package raus
+
+import (
+	"context"
+	"sync"
+)
+
+// localPubSub is in-process fan-out pub/sub for Backends without a
+// server-side broker of their own (MemoryBackend, BoltBackend).
+type localPubSub struct {
+	mu   sync.Mutex
+	subs map[string][]chan Message
+}
+
+func newLocalPubSub() *localPubSub {
+	return &localPubSub{subs: make(map[string][]chan Message)}
+}
+
+func (p *localPubSub) Publish(ctx context.Context, channel, payload string) error {
+	p.mu.Lock()
+	subs := append([]chan Message(nil), p.subs[channel]...)
+	p.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- Message{Payload: payload}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (p *localPubSub) Subscribe(ctx context.Context, channel string) (<-chan Message, error) {
+	ch := make(chan Message, 16)
+
+	p.mu.Lock()
+	p.subs[channel] = append(p.subs[channel], ch)
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		subs := p.subs[channel]
+		for i, c := range subs {
+			if c == ch {
+				p.subs[channel] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
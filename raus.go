@@ -2,30 +2,27 @@ package raus
 
 import (
 	"context"
-	crand "crypto/rand"
-	"encoding/binary"
 	"errors"
-	"fmt"
 	"log"
-	"math/rand"
-	"strconv"
-	"strings"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/satori/go.uuid"
-	"gopkg.in/redis.v5"
 )
 
 type Raus struct {
-	rand          *rand.Rand
-	uuid          string
-	id            int
-	min           int
-	max           int
-	redisAddr     string
-	namespace     string
-	pubSubChannel string
-	channel       chan error
+	uuid              string
+	id                int
+	min               int
+	max               int
+	backend           Backend
+	namespace         string
+	pubSubChannel     string
+	channel           chan error
+	events            chan Event
+	rendezvousKey     string
+	ttl               time.Duration
+	heartbeatInterval time.Duration
 }
 
 const (
@@ -33,14 +30,35 @@ const (
 	maxCandidate        = 10
 	defaultNamespace    = "raus"
 	pubSubChannelSuffix = ":broadcast"
+	defaultTTL          = 60 * time.Second
+	defaultHeartbeat    = 1 * time.Second
+	eventBufferSize     = 16
 )
 
-// New creates *Raus object.
-func New(addr string, min, max int) (*Raus, error) {
-	var s int64
-	if err := binary.Read(crand.Reader, binary.LittleEndian, &s); err != nil {
-		s = time.Now().UnixNano()
+// New creates *Raus object connecting to the single Redis node at addr.
+func New(addr string, min, max int, opts ...Option) (*Raus, error) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{addr}})
+	return NewWithBackend(NewRedisBackend(client), min, max, opts...)
+}
+
+// NewFromURI creates *Raus object from a connection string of the form
+// "addrs=redis-a:6379,redis-b:6379 db=2 password=secret tls=true master_name=mymaster idle_timeout=10s".
+// Supplying master_name selects a Sentinel-backed client, supplying more
+// than one address in addrs (without master_name) selects a Cluster
+// client, and otherwise a plain single-node client is used.
+func NewFromURI(uri string, min, max int, opts ...Option) (*Raus, error) {
+	conn, err := parseURI(uri)
+	if err != nil {
+		return nil, err
 	}
+	client := redis.NewUniversalClient(conn)
+	return NewWithBackend(NewRedisBackend(client), min, max, opts...)
+}
+
+// NewWithBackend creates *Raus object against any Backend, e.g.
+// NewMemoryBackend for tests or NewBoltBackend for a single-node
+// deployment that doesn't want Redis at all.
+func NewWithBackend(b Backend, min, max int, opts ...Option) (*Raus, error) {
 	if min < 0 {
 		return nil, errors.New("min should be greater than or equal to 0")
 	}
@@ -48,22 +66,27 @@ func New(addr string, min, max int) (*Raus, error) {
 		return nil, errors.New("max should be greater than min")
 	}
 
-	return &Raus{
-		rand:          rand.New(rand.NewSource(s)),
-		uuid:          uuid.NewV4().String(),
-		min:           min,
-		max:           max,
-		redisAddr:     addr,
-		namespace:     defaultNamespace,
-		pubSubChannel: defaultNamespace + pubSubChannelSuffix,
-		channel:       make(chan error, 0),
-	}, nil
+	r := &Raus{
+		uuid:              uuid.NewV4().String(),
+		min:               min,
+		max:               max,
+		backend:           b,
+		channel:           make(chan error, 0),
+		events:            make(chan Event, eventBufferSize),
+		ttl:               defaultTTL,
+		heartbeatInterval: defaultHeartbeat,
+	}
+	r.SetNamespace(defaultNamespace)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
-// SetNamespace sets namespace (= redis key prefix)
+// SetNamespace sets namespace (= backend key prefix).
 func (r *Raus) SetNamespace(n string) {
 	r.namespace = n
-	r.pubSubChannel = n + pubSubChannelSuffix
+	r.pubSubChannel = broadcastChannel(n)
 }
 
 func (r *Raus) size() int {
@@ -74,196 +97,179 @@ func (r *Raus) raiseError(err error) {
 	r.channel <- err
 }
 
-// Get gets unique id ranged between min and max.
-func (r *Raus) Get(ctx context.Context) (int, chan error, error) {
-	go r.subscribe(ctx)
-	err := <-r.channel
-	if err != nil {
-		return ErrorID, nil, err
+// raiseErrorCtx is raiseError for call sites reached after Get has
+// already returned a Session: by then nothing guarantees a caller is
+// still reading Errors() (e.g. one that only calls Session.Close and
+// otherwise relies on Events()), so the send must not block forever once
+// ctx is canceled.
+func (r *Raus) raiseErrorCtx(ctx context.Context, err error) {
+	select {
+	case r.channel <- err:
+	case <-ctx.Done():
 	}
-	go r.publish(ctx)
-	return r.id, r.channel, nil
 }
 
-func (r *Raus) subscribe(ctx context.Context) {
-	// table for looking up unused id
-	usedIds := make(map[int]bool, r.size())
+func (r *Raus) emitEvent(e Event) {
+	select {
+	case r.events <- e:
+	default:
+		// a slow or absent reader shouldn't block claiming, heartbeating,
+		// or shutdown.
+	}
+}
 
-	c := redis.NewClient(&redis.Options{
-		Addr: r.redisAddr,
-	})
-	defer c.Close()
+// Get claims a unique id ranged between min and max and returns a Session
+// to observe and control its lifecycle.
+func (r *Raus) Get(parent context.Context) (*Session, error) {
+	ctx, cancel := context.WithCancel(parent)
 
-	// subscribe to channel, and reading other's id (3 sec)
-	pubsub, err := c.Subscribe(r.pubSubChannel)
-	if err != nil {
-		r.raiseError(err)
-		return
-	}
-	timeout := 3 * time.Second
-	start := time.Now()
-LISTING:
-	for time.Since(start) < timeout {
-		select {
-		case <-ctx.Done():
-			r.raiseError(errors.New("canceled"))
-			return
-		default:
-		}
-		_msg, err := pubsub.ReceiveTimeout(timeout)
-		if err != nil {
-			break LISTING
-		}
-		switch msg := _msg.(type) {
-		case *redis.Message:
-			xuuid, xid, err := parsePayload(msg.Payload)
-			if err != nil {
-				log.Println(err)
-				break
-			}
-			if xuuid == r.uuid {
-				// other's uuid is same to myself (X_X)
-				r.raiseError(errors.New("duplicate uuid"))
-				return
-			}
-			log.Printf("xuuid:%s xid:%d", xuuid, xid)
-			usedIds[xid] = true
-		case *redis.Subscription:
-		default:
-			r.raiseError(fmt.Errorf("unknown redis message: %#v", _msg))
-			return
-		}
-	}
+	subscribeDone := make(chan struct{})
+	go func() {
+		defer close(subscribeDone)
+		r.subscribe(ctx)
+	}()
 
-	pubsub.Unsubscribe()
-	if ctx.Err() != nil {
-		r.raiseError(errors.New("canceled"))
-		return
+	if err := <-r.channel; err != nil {
+		cancel()
+		<-subscribeDone
+		return nil, err
 	}
 
-LOCKING:
-	for {
-		candidate := make([]int, 0, maxCandidate)
-		for i := r.min; i <= r.max; i++ {
-			if usedIds[i] {
-				continue
-			}
-			candidate = append(candidate, i)
-			if len(candidate) >= maxCandidate {
-				break
-			}
-		}
-		if len(candidate) == 0 {
-			r.raiseError(errors.New("no more available id"))
-			return
-		}
-		log.Printf("candidate ids: %v", candidate)
-		// pick up randomly
-		id := candidate[r.rand.Intn(len(candidate))]
+	publishDone := make(chan struct{})
+	go func() {
+		defer close(publishDone)
+		r.publish(ctx)
+	}()
 
-		// try to lock by SET NX
-		log.Println("trying to get lock key", r.candidateLockKey(id))
-		res := c.SetNX(
-			r.candidateLockKey(id), // key
-			r.uuid,                 // value
-			60*time.Second,         // expiration
-		)
-		if err := res.Err(); err != nil {
-			r.raiseError(err)
-			return
-		}
-		if res.Val() {
-			log.Println("got lock for", id)
-			r.id = id
-			r.channel <- nil // success!
-			break LOCKING
-		} else {
-			log.Println("could not get lock for", id)
-			usedIds[id] = true
-		}
-	}
+	return &Session{
+		r:             r,
+		id:            r.id,
+		errors:        r.channel,
+		events:        r.events,
+		cancel:        cancel,
+		subscribeDone: subscribeDone,
+		publishDone:   publishDone,
+	}, nil
+}
 
-	pubsub, err = c.Subscribe(r.pubSubChannel)
+func (r *Raus) subscribe(ctx context.Context) {
+	// the backend's ClaimInRange picks and locks an id atomically, so
+	// there is no listen-first race to win here; the pub/sub channel
+	// below is only a liveness heartbeat and duplicate-detection safety
+	// net.
+	id, err := r.claimWithRetry(ctx)
 	if err != nil {
 		r.raiseError(err)
+		return
 	}
-WATCHING:
-	for {
-		msg, err := pubsub.ReceiveMessage()
-		if err != nil {
-			log.Println(err)
-			continue WATCHING
-		}
+	log.Println("got lock for", id)
+	r.id = id
+	claimsTotal.Inc()
+	r.updateRangeUtilization(ctx)
+	r.channel <- nil // success!
+	r.emitEvent(Event{Kind: Acquired, ID: id})
+
+	msgs, err := r.backend.Subscribe(ctx, r.pubSubChannel)
+	if err != nil {
+		r.raiseErrorCtx(ctx, err)
+		return
+	}
+	for msg := range msgs {
 		xuuid, xid, err := parsePayload(msg.Payload)
 		if err != nil {
 			log.Println(err)
-			continue WATCHING
+			continue
 		}
 		if xid == r.id && xuuid != r.uuid {
 			log.Printf("duplicate id %d from %s", xid, xuuid)
-			r.raiseError(errors.New("duplicate id detected"))
+			duplicateDetectedTotal.Inc()
+			r.emitEvent(Event{Kind: LockLost, ID: r.id})
+			r.raiseErrorCtx(ctx, errors.New("duplicate id detected"))
 			return
 		}
+		if xuuid == r.uuid {
+			// our own heartbeat echoed back
+			continue
+		}
+		r.emitEvent(Event{Kind: PeerSeen, ID: xid})
 	}
+	// msgs only closes when ctx is done (graceful shutdown) or the
+	// backend's Subscribe itself failed; either way, don't block forever
+	// delivering this if Close already canceled ctx and nobody is
+	// reading Errors() anymore.
+	r.raiseErrorCtx(ctx, errors.New("canceled"))
 }
 
-func parsePayload(payload string) (string, int, error) {
-	s := strings.Split(payload, ":")
-	if len(s) != 2 {
-		return "", 0, fmt.Errorf("unexpected data %s", payload)
-	}
-	id, err := strconv.Atoi(s[1])
+// updateRangeUtilization scans the configured range for free ids and
+// records the result under this Raus's namespace, so operators watching
+// several namespaces in one process each get their own gauge value.
+func (r *Raus) updateRangeUtilization(ctx context.Context) {
+	free, err := r.backend.ListFree(ctx, r.namespace, r.min, r.max, r.size()+1)
 	if err != nil {
-		return "", 0, fmt.Errorf("unexpected data %s", payload)
+		log.Println(err)
+		return
 	}
-	return s[0], id, nil
-}
-
-func newPayload(uuid string, id int) string {
-	return fmt.Sprintf("%s:%d", uuid, id)
+	rangeUtilization.WithLabelValues(r.namespace).Set(1 - float64(len(free))/float64(r.size()+1))
 }
 
 func (r *Raus) publish(ctx context.Context) {
-	c := redis.NewClient(&redis.Options{
-		Addr: r.redisAddr,
-	})
-	defer c.Close()
-
-	ticker := time.NewTicker(1 * time.Second)
-	payload := newPayload(r.uuid, r.id)
-TICKER:
-	for range ticker.C {
+	ticker := time.NewTicker(r.heartbeatInterval)
+	defer ticker.Stop()
+	attempt := 0
+	for {
 		select {
 		case <-ctx.Done():
 			log.Println("shutting down")
 			// returns after releasing a held lock
-			err := c.Del(r.lockKey()).Err()
-			if err != nil {
+			// ctx is already canceled here, and a network backend would
+			// refuse to run the release under it.
+			if err := r.backend.Release(context.Background(), r.lockKey(), r.uuid); err != nil {
 				log.Println(err)
 			} else {
 				log.Printf("remove a lock key %s successfully", r.lockKey())
+				r.emitEvent(Event{Kind: Released, ID: r.id})
 			}
 			return
-		default:
-			err := c.Publish(r.pubSubChannel, payload).Err()
-			if err != nil {
-				log.Println(err)
-				continue TICKER
-			}
-			// update expiration
-			err = c.Set(r.lockKey(), r.uuid, 60*time.Second).Err()
-			if err != nil {
+		case <-ticker.C:
+			if err := r.renew(ctx); err != nil {
 				log.Println(err)
-				continue TICKER
+				attempt = r.backoff(ctx, attempt)
+				continue
 			}
+			attempt = 0
 		}
 	}
 }
 
-func (r *Raus) lockKey() string {
-	return fmt.Sprintf("%s:id:%d", r.namespace, r.id)
+// renew republishes the heartbeat and refreshes the lock's TTL. Get's
+// background publish loop calls it on every tick; Session.Renew lets a
+// caller do the same on demand.
+func (r *Raus) renew(ctx context.Context) error {
+	payload := newPayload(r.uuid, r.id)
+	if err := r.backend.Publish(ctx, r.pubSubChannel, payload); err != nil {
+		return err
+	}
+	r.emitEvent(Event{Kind: Republished, ID: r.id})
+
+	if err := r.backend.Refresh(ctx, r.lockKey(), r.uuid, r.ttl); err != nil {
+		refreshFailuresTotal.Inc()
+		return err
+	}
+	r.emitEvent(Event{Kind: Refreshed, ID: r.id})
+	r.updateRangeUtilization(ctx)
+	return nil
 }
 
-func (r *Raus) candidateLockKey(id int) string {
-	return fmt.Sprintf("%s:id:%d", r.namespace, id)
+// backoff waits out a jittered exponential delay for the given attempt
+// count (or until ctx is canceled) and returns the next attempt count.
+func (r *Raus) backoff(ctx context.Context, attempt int) int {
+	select {
+	case <-ctx.Done():
+	case <-time.After(backoffDuration(attempt)):
+	}
+	return attempt + 1
+}
+
+func (r *Raus) lockKey() string {
+	return lockKey(r.namespace, r.id)
 }
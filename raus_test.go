@@ -0,0 +1,81 @@
+package raus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentClaims(t *testing.T) {
+	const n = 8
+	backend := NewMemoryBackend()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[int]bool)
+		wg   sync.WaitGroup
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			r, err := NewWithBackend(backend, 0, n-1)
+			if err != nil {
+				t.Errorf("NewWithBackend: %v", err)
+				return
+			}
+
+			// Intentionally never canceled: canceling would release the
+			// lock via the publish goroutine and let another claimant
+			// pick up the freed id mid-test, which is exactly the
+			// overlap this test checks for.
+			session, err := r.Get(context.Background())
+			if err != nil {
+				t.Errorf("Get: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[session.ID()] {
+				t.Errorf("id %d claimed twice", session.ID())
+			}
+			seen[session.ID()] = true
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != n {
+		t.Fatalf("got %d distinct ids, want %d", len(seen), n)
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	backend := NewMemoryBackend()
+	r, err := NewWithBackend(backend, 0, 1, WithHeartbeatInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWithBackend: %v", err)
+	}
+
+	session, err := r.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := session.Close(closeCtx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	ok, err := backend.TryClaim(context.Background(), lockKey(r.namespace, session.ID()), "someone-else", time.Second)
+	if err != nil {
+		t.Fatalf("TryClaim after Close: %v", err)
+	}
+	if !ok {
+		t.Fatalf("lock key still held after Close")
+	}
+}
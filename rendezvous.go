@@ -0,0 +1,23 @@
+package raus
+
+import (
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// rendezvousPick applies rendezvous (highest random weight) hashing to
+// choose one id from candidates: the id whose hash of "nodeKey:id" is
+// largest wins.
+func rendezvousPick(nodeKey string, candidates []int) int {
+	var winner int
+	var winnerHash uint64
+	for i, id := range candidates {
+		h := xxhash.Sum64String(nodeKey + ":" + strconv.Itoa(id))
+		if i == 0 || h > winnerHash {
+			winner = id
+			winnerHash = h
+		}
+	}
+	return winner
+}
@@ -0,0 +1,58 @@
+package raus
+
+import "context"
+
+// Session is the handle returned by Get. It replaces a single fire-and-
+// forget error channel with a view onto TTL refreshes, heartbeat
+// publishes, peer sightings, and lock loss, plus Renew and Close for
+// manual heartbeat and graceful shutdown.
+type Session struct {
+	r             *Raus
+	id            int
+	errors        chan error
+	events        chan Event
+	cancel        context.CancelFunc
+	subscribeDone chan struct{}
+	publishDone   chan struct{}
+}
+
+// ID returns the id this session claimed.
+func (s *Session) ID() int {
+	return s.id
+}
+
+// Errors delivers terminal errors: a duplicate id (the lock loss also
+// reported as a LockLost event), a failed subscribe, or cancellation. The
+// session is no longer usable once a value arrives. A failed TTL refresh
+// is not delivered here; it is logged and retried with backoff.
+func (s *Session) Errors() <-chan error {
+	return s.errors
+}
+
+// Events delivers lifecycle notifications; see EventKind. Events are
+// dropped rather than blocking the session if the caller isn't reading
+// fast enough.
+func (s *Session) Events() <-chan Event {
+	return s.events
+}
+
+// Renew immediately refreshes the lock and re-publishes the heartbeat
+// instead of waiting for the next tick.
+func (s *Session) Renew(ctx context.Context) error {
+	return s.r.renew(ctx)
+}
+
+// Close releases the lock and waits for both the publish and subscribe
+// goroutines to exit before returning, or for ctx to be canceled,
+// whichever comes first.
+func (s *Session) Close(ctx context.Context) error {
+	s.cancel()
+	for _, done := range [...]chan struct{}{s.publishDone, s.subscribeDone} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}